@@ -9,6 +9,7 @@ import (
     "io"
     "net/http"
     "strings"
+    "sync"
     "time"
 )
 
@@ -16,6 +17,10 @@ type StreamResponse struct {
     Text string
     Done bool
     Err  error
+
+    // ToolCall is set once a streamed tool-call's arguments have been fully
+    // accumulated (see StreamChatWithTools). Text is empty on such a value.
+    ToolCall *ToolCall
 }
 
 type ApiClient struct {
@@ -23,15 +28,35 @@ type ApiClient struct {
     apiKey    string
     sessionID string
     http      *http.Client
+
+    mu           sync.Mutex
+    readDeadline time.Time
+    idleTimeout  time.Duration
+
+    // newDecoder builds a fresh EventDecoder for each stream when set via
+    // WithEventDecoder; nil means auto-detect (see newStreamDecoder).
+    newDecoder func() EventDecoder
+
+    // resumable enables the opt-in reconnect-on-failure mode (see WithResume).
+    resumable bool
+    // MaxReconnects caps how many times a resumable stream will reopen the
+    // request after a transient failure before giving up and surfacing the
+    // error. Only consulted when the client was built with WithResume.
+    MaxReconnects int
 }
 
-func NewApiClient(apiURL, apiKey, sessionID string) *ApiClient {
-    return &ApiClient{
-        apiURL:    apiURL,
-        apiKey:    apiKey,
-        sessionID: sessionID,
-        http: &http.Client{Timeout: 0}, // infinite for streaming; we manage via context
+func NewApiClient(apiURL, apiKey, sessionID string, opts ...Option) *ApiClient {
+    c := &ApiClient{
+        apiURL:        apiURL,
+        apiKey:        apiKey,
+        sessionID:     sessionID,
+        http:          &http.Client{Timeout: 0}, // infinite for streaming; we manage via context
+        MaxReconnects: defaultMaxReconnects,
+    }
+    for _, opt := range opts {
+        opt(c)
     }
+    return c
 }
 
 func (c *ApiClient) endpoint() string {
@@ -40,10 +65,21 @@ func (c *ApiClient) endpoint() string {
 }
 
 func (c *ApiClient) StreamChat(ctx context.Context, message string, stream bool) (<-chan StreamResponse, error) {
+    return c.streamMessages(ctx, []Message{{Role: "user", Content: message}}, stream, nil)
+}
+
+// buildChatRequest builds the chat POST request. lastEventID, when non-empty,
+// is sent as Last-Event-ID so a reconnecting resumable stream (see
+// WithResume) can pick up where it left off. tools, when non-empty, is sent
+// so the model may respond with a tool call (see StreamChatWithTools).
+func (c *ApiClient) buildChatRequest(ctx context.Context, messages []Message, stream bool, lastEventID string, tools []ToolSpec) (*http.Request, error) {
     body := map[string]any{
-        "messages": []map[string]string{{"role": "user", "content": message}},
+        "messages": messages,
         "stream":   stream,
     }
+    if len(tools) > 0 {
+        body["tools"] = tools
+    }
     b, _ := json.Marshal(body)
 
     req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint(), bytes.NewReader(b))
@@ -52,7 +88,16 @@ func (c *ApiClient) StreamChat(ctx context.Context, message string, stream bool)
     if stream { req.Header.Set("Accept", "text/event-stream") } else { req.Header.Set("Accept", "application/json") }
     if c.apiKey != "" { req.Header.Set("X-API-Key", c.apiKey) }
     if c.sessionID != "" { req.Header.Set("X-Session-ID", c.sessionID) }
+    if lastEventID != "" { req.Header.Set("Last-Event-ID", lastEventID) }
     req.Header.Set("X-Request-ID", time.Now().Format(time.RFC3339Nano))
+    return req, nil
+}
+
+// streamMessages sends the full message history in a single request, underlying
+// both the single-shot StreamChat and Conversation.Send/SendStream.
+func (c *ApiClient) streamMessages(ctx context.Context, messages []Message, stream bool, tools []ToolSpec) (<-chan StreamResponse, error) {
+    req, err := c.buildChatRequest(ctx, messages, stream, "", tools)
+    if err != nil { return nil, err }
 
     resp, err := c.http.Do(req)
     if err != nil { return nil, err }
@@ -82,45 +127,36 @@ func (c *ApiClient) StreamChat(ctx context.Context, message string, stream bool)
         return ch, nil
     }
 
+    decoder := c.newStreamDecoder(resp.Header.Get("Content-Type"))
+
+    if c.resumable {
+        go c.runResumableStream(ctx, messages, tools, resp, decoder, ch)
+        return ch, nil
+    }
+
     go func() {
         defer close(ch)
         defer resp.Body.Close()
         reader := bufio.NewReader(resp.Body)
+        idle, absolute := c.deadlineSnapshot()
+        dt := newDeadlineTimer(idle, absolute)
+        defer dt.stop()
         for {
-            line, err := reader.ReadString('\n')
+            line, err := dt.readLine(reader)
             if err != nil {
                 if errors.Is(err, io.EOF) { return }
+                if errors.Is(err, ErrDeadlineExceeded) {
+                    resp.Body.Close() // unblock the in-flight ReadString
+                }
                 ch <- StreamResponse{Err: err}
                 return
             }
             line = strings.TrimSpace(line)
             if line == "" { continue }
-            if strings.HasPrefix(line, "data: ") {
-                payload := strings.TrimSpace(line[6:])
-                if payload == "" || payload == "[DONE]" {
-                    ch <- StreamResponse{Text: "", Done: true}
-                    return
-                }
-                var v map[string]any
-                if err := json.Unmarshal([]byte(payload), &v); err == nil {
-                    if errObj, ok := v["error"].(map[string]any); ok {
-                        if msg, ok := errObj["message"].(string); ok {
-                            ch <- StreamResponse{Err: errors.New(msg)}
-                            return
-                        }
-                    }
-                    if s, ok := v["response"].(string); ok {
-                        done := false
-                        if d, ok := v["done"].(bool); ok { done = d }
-                        ch <- StreamResponse{Text: s, Done: done}
-                        if done { ch <- StreamResponse{Text: "", Done: true}; return }
-                    }
-                } else {
-                    ch <- StreamResponse{Text: payload, Done: false}
-                }
-            } else {
-                ch <- StreamResponse{Text: line, Done: false}
-            }
+            r, ok := decoder.Feed(line)
+            if !ok { continue }
+            ch <- r
+            if r.Done || r.Err != nil { return }
         }
     }()
 
@@ -0,0 +1,199 @@
+package orbit
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+)
+
+// Message is a single turn in a conversation, matching the server's
+// {role, content} message shape.
+type Message struct {
+    Role    string `json:"role"`
+    Content string `json:"content"`
+
+    // ToolCallID identifies the tool call this message answers. It is only
+    // set on "tool" role messages produced by StreamChatWithTools.
+    ToolCallID string `json:"tool_call_id,omitempty"`
+
+    // ToolCalls carries the tool calls requested by an assistant message, so
+    // an OpenAI-compatible backend can correlate a later "tool" role
+    // message's ToolCallID with the call it answers. Only set on assistant
+    // messages produced by StreamChatWithTools.
+    ToolCalls []ToolCallRequest `json:"tool_calls,omitempty"`
+}
+
+// Conversation accumulates message history across multiple StreamChat calls
+// so a caller can hold a multi-turn exchange instead of one-shot prompts.
+// It is not safe for concurrent use.
+type Conversation struct {
+    client       *ApiClient
+    systemPrompt string
+    messages     []Message
+
+    maxMessages      int
+    maxTokenEstimate int
+}
+
+// conversationState is the on-disk/wire representation used by SaveJSON and
+// RestoreConversation.
+type conversationState struct {
+    SystemPrompt string    `json:"system_prompt,omitempty"`
+    Messages     []Message `json:"messages"`
+}
+
+// NewConversation starts a new multi-turn conversation bound to this client.
+// systemPrompt may be empty, in which case no system message is sent.
+func (c *ApiClient) NewConversation(systemPrompt string) *Conversation {
+    return &Conversation{
+        client:       c,
+        systemPrompt: systemPrompt,
+    }
+}
+
+// SetMaxMessages caps the number of non-system messages retained in history;
+// the oldest messages are dropped first. A value <= 0 disables the cap.
+func (conv *Conversation) SetMaxMessages(n int) {
+    conv.maxMessages = n
+    conv.trim()
+}
+
+// SetMaxTokenEstimate caps the estimated token size of the retained history,
+// dropping the oldest messages until the estimate fits. A value <= 0
+// disables the cap. The estimate is a rough heuristic (~4 characters per
+// token), not an exact tokenizer count.
+func (conv *Conversation) SetMaxTokenEstimate(n int) {
+    conv.maxTokenEstimate = n
+    conv.trim()
+}
+
+// History returns a copy of the accumulated messages, including the system
+// prompt if one was set.
+func (conv *Conversation) History() []Message {
+    out := make([]Message, 0, len(conv.messages)+1)
+    if conv.systemPrompt != "" {
+        out = append(out, Message{Role: "system", Content: conv.systemPrompt})
+    }
+    out = append(out, conv.messages...)
+    return out
+}
+
+// Send appends message as a user turn, sends the full history, and returns
+// the assistant's reply text once it is complete. On any error, the user
+// turn is rolled back so history is left exactly as it was before the call.
+func (conv *Conversation) Send(ctx context.Context, message string) (string, error) {
+    before := conv.snapshot()
+    ch, err := conv.send(ctx, message, false)
+    if err != nil {
+        conv.restore(before)
+        return "", err
+    }
+    var text string
+    for r := range ch {
+        if r.Err != nil {
+            conv.restore(before)
+            return "", r.Err
+        }
+        text += r.Text
+    }
+    conv.messages = append(conv.messages, Message{Role: "assistant", Content: text})
+    conv.trim()
+    return text, nil
+}
+
+// SendStream appends message as a user turn and streams the assistant's
+// reply. The reply is appended to history once the stream completes; on
+// error, the user turn is rolled back so history is left exactly as it was
+// before the call.
+func (conv *Conversation) SendStream(ctx context.Context, message string) (<-chan StreamResponse, error) {
+    before := conv.snapshot()
+    ch, err := conv.send(ctx, message, true)
+    if err != nil {
+        conv.restore(before)
+        return nil, err
+    }
+    out := make(chan StreamResponse)
+    go func() {
+        defer close(out)
+        var text string
+        for r := range ch {
+            if r.Text != "" {
+                text += r.Text
+            }
+            out <- r
+            if r.Err != nil {
+                conv.restore(before)
+                return
+            }
+        }
+        conv.messages = append(conv.messages, Message{Role: "assistant", Content: text})
+        conv.trim()
+    }()
+    return out, nil
+}
+
+func (conv *Conversation) send(ctx context.Context, message string, stream bool) (<-chan StreamResponse, error) {
+    conv.messages = append(conv.messages, Message{Role: "user", Content: message})
+    conv.trim()
+    return conv.client.streamMessages(ctx, conv.History(), stream, nil)
+}
+
+// snapshot captures the current history so it can be restored with restore
+// if the in-flight request fails.
+func (conv *Conversation) snapshot() []Message {
+    return append([]Message(nil), conv.messages...)
+}
+
+func (conv *Conversation) restore(snapshot []Message) {
+    conv.messages = snapshot
+}
+
+// trim enforces maxMessages and maxTokenEstimate by dropping the oldest
+// non-system messages; the system prompt itself is never dropped.
+func (conv *Conversation) trim() {
+    if conv.maxMessages > 0 {
+        for len(conv.messages) > conv.maxMessages {
+            conv.messages = conv.messages[1:]
+        }
+    }
+    if conv.maxTokenEstimate > 0 {
+        for len(conv.messages) > 0 && conv.estimateTokens() > conv.maxTokenEstimate {
+            conv.messages = conv.messages[1:]
+        }
+    }
+}
+
+func (conv *Conversation) estimateTokens() int {
+    chars := len(conv.systemPrompt)
+    for _, m := range conv.messages {
+        chars += len(m.Content)
+    }
+    // Rough heuristic: ~4 characters per token.
+    return chars / 4
+}
+
+// SaveJSON serializes the conversation's system prompt and history so it can
+// be restored with RestoreConversation after a process restart.
+func (conv *Conversation) SaveJSON() ([]byte, error) {
+    return json.Marshal(conversationState{
+        SystemPrompt: conv.systemPrompt,
+        Messages:     conv.messages,
+    })
+}
+
+// RestoreConversation reconstructs a Conversation previously saved with
+// SaveJSON, bound to client.
+func RestoreConversation(client *ApiClient, data []byte) (*Conversation, error) {
+    if client == nil {
+        return nil, errors.New("orbit: RestoreConversation requires a non-nil client")
+    }
+    var state conversationState
+    if err := json.Unmarshal(data, &state); err != nil {
+        return nil, err
+    }
+    return &Conversation{
+        client:       client,
+        systemPrompt: state.SystemPrompt,
+        messages:     state.Messages,
+    }, nil
+}
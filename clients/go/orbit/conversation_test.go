@@ -0,0 +1,38 @@
+package orbit
+
+import "testing"
+
+func TestConversationTrim_MaxMessages(t *testing.T) {
+    conv := &Conversation{}
+    for i := 0; i < 5; i++ {
+        conv.messages = append(conv.messages, Message{Role: "user", Content: "x"})
+    }
+    conv.SetMaxMessages(3)
+    if len(conv.messages) != 3 {
+        t.Fatalf("expected 3 messages after trim, got %d", len(conv.messages))
+    }
+}
+
+func TestConversationTrim_MaxTokenEstimate(t *testing.T) {
+    conv := &Conversation{}
+    for i := 0; i < 10; i++ {
+        conv.messages = append(conv.messages, Message{Role: "user", Content: "aaaaaaaaaaaaaaaa"}) // 16 chars ~= 4 tokens
+    }
+    conv.SetMaxTokenEstimate(8)
+    if conv.estimateTokens() > 8 {
+        t.Fatalf("estimateTokens() = %d, want <= 8 after trim", conv.estimateTokens())
+    }
+}
+
+func TestConversationSnapshotRestore(t *testing.T) {
+    conv := &Conversation{}
+    conv.messages = append(conv.messages, Message{Role: "user", Content: "hi"})
+    before := conv.snapshot()
+
+    conv.messages = append(conv.messages, Message{Role: "user", Content: "oops, failed turn"})
+    conv.restore(before)
+
+    if len(conv.messages) != 1 || conv.messages[0].Content != "hi" {
+        t.Fatalf("restore did not roll back to the snapshot: %+v", conv.messages)
+    }
+}
@@ -0,0 +1,120 @@
+package orbit
+
+import (
+    "bufio"
+    "errors"
+    "sync"
+    "time"
+)
+
+// ErrDeadlineExceeded is surfaced on StreamResponse.Err when a stream is
+// torn down by SetReadDeadline or SetIdleTimeout rather than by the caller
+// cancelling ctx or the server closing the connection.
+var ErrDeadlineExceeded = errors.New("orbit: stream deadline exceeded")
+
+// SetReadDeadline sets an absolute point in time by which the next streaming
+// read must produce data, after which the stream terminates with
+// ErrDeadlineExceeded. A zero Time disables it. It applies to streams
+// started after the call.
+func (c *ApiClient) SetReadDeadline(t time.Time) {
+    c.mu.Lock()
+    c.readDeadline = t
+    c.mu.Unlock()
+}
+
+// SetIdleTimeout sets a sliding timeout that resets on every line received
+// from the server; if no data arrives within d, the stream terminates with
+// ErrDeadlineExceeded. A zero duration disables it. It applies to streams
+// started after the call. SetIdleTimeout takes precedence over
+// SetReadDeadline when both are set.
+func (c *ApiClient) SetIdleTimeout(d time.Duration) {
+    c.mu.Lock()
+    c.idleTimeout = d
+    c.mu.Unlock()
+}
+
+func (c *ApiClient) deadlineSnapshot() (idle time.Duration, absolute time.Time) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return c.idleTimeout, c.readDeadline
+}
+
+// deadlineTimer mirrors the cancel-channel-closed-by-AfterFunc pattern used
+// for Go's network deadlines: a timer closes a cancel channel once, and
+// readers select between their blocking read completing and cancel firing.
+//
+// An idle timeout slides on every read, which rules out reusing a single
+// timer across reads: time.Timer.Reset races an in-flight AfterFunc
+// callback per the stdlib docs, and under jitter that can close an already-
+// closed cancel channel and panic. So for idle mode, readLine scopes a
+// fresh timer and cancel channel to each individual read instead of
+// resetting a shared one. An absolute read deadline doesn't slide, so it
+// keeps a single timer for the whole stream.
+type deadlineTimer struct {
+    idle     time.Duration
+    absolute time.Time
+
+    absCancel chan struct{}
+    absTimer  *time.Timer
+    absOnce   sync.Once
+}
+
+func newDeadlineTimer(idle time.Duration, absolute time.Time) *deadlineTimer {
+    dt := &deadlineTimer{idle: idle, absolute: absolute}
+    if idle <= 0 && !absolute.IsZero() {
+        dt.absCancel = make(chan struct{})
+        d := time.Until(absolute)
+        if d < 0 {
+            d = 0
+        }
+        dt.absTimer = time.AfterFunc(d, func() { dt.absOnce.Do(func() { close(dt.absCancel) }) })
+    }
+    return dt
+}
+
+func (dt *deadlineTimer) stop() {
+    if dt.absTimer != nil {
+        dt.absTimer.Stop()
+    }
+}
+
+type lineResult struct {
+    line string
+    err  error
+}
+
+// readLine reads the next line from reader, returning ErrDeadlineExceeded if
+// the configured deadline fires first. When no deadline is configured (the
+// common case on a high-frequency SSE stream), it reads directly instead of
+// paying for a goroutine and channel per line.
+func (dt *deadlineTimer) readLine(reader *bufio.Reader) (string, error) {
+    if dt.idle <= 0 && dt.absCancel == nil {
+        return reader.ReadString('\n')
+    }
+
+    resCh := make(chan lineResult, 1)
+    go func() {
+        line, err := reader.ReadString('\n')
+        resCh <- lineResult{line, err}
+    }()
+
+    if dt.idle > 0 {
+        cancel := make(chan struct{})
+        var once sync.Once
+        timer := time.AfterFunc(dt.idle, func() { once.Do(func() { close(cancel) }) })
+        defer timer.Stop()
+        select {
+        case res := <-resCh:
+            return res.line, res.err
+        case <-cancel:
+            return "", ErrDeadlineExceeded
+        }
+    }
+
+    select {
+    case res := <-resCh:
+        return res.line, res.err
+    case <-dt.absCancel:
+        return "", ErrDeadlineExceeded
+    }
+}
@@ -0,0 +1,67 @@
+package orbit
+
+import (
+    "bufio"
+    "errors"
+    "io"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestReadLineNoDeadline(t *testing.T) {
+    dt := newDeadlineTimer(0, time.Time{})
+    defer dt.stop()
+
+    reader := bufio.NewReader(strings.NewReader("hello\n"))
+    line, err := dt.readLine(reader)
+    if err != nil {
+        t.Fatalf("readLine: %v", err)
+    }
+    if line != "hello\n" {
+        t.Fatalf("line = %q, want %q", line, "hello\n")
+    }
+}
+
+func TestReadLineIdleTimeoutExceeded(t *testing.T) {
+    r, w := io.Pipe()
+    defer w.Close()
+    dt := newDeadlineTimer(10*time.Millisecond, time.Time{})
+    defer dt.stop()
+
+    _, err := dt.readLine(bufio.NewReader(r))
+    if !errors.Is(err, ErrDeadlineExceeded) {
+        t.Fatalf("err = %v, want ErrDeadlineExceeded", err)
+    }
+}
+
+func TestReadLineAbsoluteDeadlineExceeded(t *testing.T) {
+    r, w := io.Pipe()
+    defer w.Close()
+    dt := newDeadlineTimer(0, time.Now().Add(10*time.Millisecond))
+    defer dt.stop()
+
+    _, err := dt.readLine(bufio.NewReader(r))
+    if !errors.Is(err, ErrDeadlineExceeded) {
+        t.Fatalf("err = %v, want ErrDeadlineExceeded", err)
+    }
+}
+
+func TestReadLineIdleTakesPrecedenceOverAbsolute(t *testing.T) {
+    // SetIdleTimeout documents that it takes precedence over
+    // SetReadDeadline; newDeadlineTimer only arms the absolute timer when
+    // idle <= 0, so an idle timeout configured alongside a (later) absolute
+    // deadline should win and fire on its own schedule.
+    dt := newDeadlineTimer(5*time.Millisecond, time.Now().Add(time.Hour))
+    defer dt.stop()
+    if dt.absTimer != nil {
+        t.Fatal("absolute timer should not be armed when an idle timeout is set")
+    }
+
+    r, w := io.Pipe()
+    defer w.Close()
+    _, err := dt.readLine(bufio.NewReader(r))
+    if !errors.Is(err, ErrDeadlineExceeded) {
+        t.Fatalf("err = %v, want ErrDeadlineExceeded", err)
+    }
+}
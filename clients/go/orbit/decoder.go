@@ -0,0 +1,234 @@
+package orbit
+
+import (
+    "encoding/json"
+    "errors"
+    "strings"
+)
+
+// EventDecoder turns raw lines from a streaming chat response into
+// StreamResponse values, decoupling StreamChat from any single backend's
+// wire format.
+type EventDecoder interface {
+    // Feed processes one line already trimmed of surrounding whitespace.
+    // ok is false when the decoder treats the line as carrying no content of
+    // its own and it should be skipped. The OpenAI and Anthropic decoders
+    // skip SSE framing lines this way; orbitDecoder instead forwards any
+    // line without a "data: " prefix as plain text.
+    Feed(line string) (resp StreamResponse, ok bool)
+}
+
+// Option configures an ApiClient at construction time.
+type Option func(*ApiClient)
+
+// WithEventDecoder pins the client to a specific EventDecoder format instead
+// of auto-detecting one from the response Content-Type and payload shape.
+// newDecoder is called once per stream (rather than the decoder being
+// shared across streams) since decoders such as the Anthropic and sniffing
+// ones carry per-stream state and are not safe to reuse across concurrent
+// StreamChat calls.
+func WithEventDecoder(newDecoder func() EventDecoder) Option {
+    return func(c *ApiClient) { c.newDecoder = newDecoder }
+}
+
+// newStreamDecoder picks an EventDecoder for one stream: the client's
+// WithEventDecoder factory if set, otherwise ollamaDecoder for an
+// "application/x-ndjson" Content-Type, and for any other Content-Type
+// (including the generic "text/event-stream" shared by several backends)
+// sniffing the first payload's JSON shape. A fresh instance is returned on every call so
+// concurrent streams never share decoder state.
+func (c *ApiClient) newStreamDecoder(contentType string) EventDecoder {
+    if c.newDecoder != nil {
+        return c.newDecoder()
+    }
+    if strings.Contains(contentType, "application/x-ndjson") {
+        return &ollamaDecoder{}
+    }
+    return &sniffingDecoder{}
+}
+
+// orbitDecoder parses Orbit's native {response, done} SSE shape.
+type orbitDecoder struct {
+    toolCalls toolCallAccumulator
+}
+
+func (d *orbitDecoder) Feed(line string) (StreamResponse, bool) {
+    if !strings.HasPrefix(line, "data: ") {
+        // Not SSE framing: treat as a plain chunked-text response and
+        // forward the line as-is, same as a non-SSE StreamChat response.
+        return StreamResponse{Text: line}, true
+    }
+    payload := strings.TrimSpace(line[len("data: "):])
+    if payload == "" || payload == "[DONE]" {
+        return StreamResponse{Done: true}, true
+    }
+    var v struct {
+        Response  string          `json:"response"`
+        Done      bool            `json:"done"`
+        ToolCalls []toolCallDelta `json:"tool_calls"`
+        Error     *struct {
+            Message string `json:"message"`
+        } `json:"error"`
+    }
+    if err := json.Unmarshal([]byte(payload), &v); err != nil {
+        return StreamResponse{Text: payload}, true
+    }
+    if v.Error != nil && v.Error.Message != "" {
+        return StreamResponse{Err: errors.New(v.Error.Message)}, true
+    }
+    if tc := d.toolCalls.feed(v.ToolCalls, v.Done); tc != nil {
+        return StreamResponse{ToolCall: tc, Done: true}, true
+    }
+    return StreamResponse{Text: v.Response, Done: v.Done}, true
+}
+
+// openAIDecoder parses OpenAI-compatible choices[].delta.content chunks and
+// choices[].delta.tool_calls fragments.
+type openAIDecoder struct {
+    toolCalls toolCallAccumulator
+}
+
+func (d *openAIDecoder) Feed(line string) (StreamResponse, bool) {
+    if !strings.HasPrefix(line, "data: ") {
+        return StreamResponse{}, false
+    }
+    payload := strings.TrimSpace(line[len("data: "):])
+    if payload == "" || payload == "[DONE]" {
+        return StreamResponse{Done: true}, true
+    }
+    var v struct {
+        Choices []struct {
+            Delta struct {
+                Content   string          `json:"content"`
+                ToolCalls []toolCallDelta `json:"tool_calls"`
+            } `json:"delta"`
+            FinishReason *string `json:"finish_reason"`
+        } `json:"choices"`
+        Error *struct {
+            Message string `json:"message"`
+        } `json:"error"`
+    }
+    if err := json.Unmarshal([]byte(payload), &v); err != nil {
+        return StreamResponse{Text: payload}, true
+    }
+    if v.Error != nil && v.Error.Message != "" {
+        return StreamResponse{Err: errors.New(v.Error.Message)}, true
+    }
+    if len(v.Choices) == 0 {
+        return StreamResponse{}, true
+    }
+    choice := v.Choices[0]
+    toolCallsDone := choice.FinishReason != nil && *choice.FinishReason == "tool_calls"
+    if tc := d.toolCalls.feed(choice.Delta.ToolCalls, toolCallsDone); tc != nil {
+        return StreamResponse{ToolCall: tc, Done: true}, true
+    }
+    return StreamResponse{Text: choice.Delta.Content, Done: choice.FinishReason != nil}, true
+}
+
+// anthropicDecoder parses Anthropic's content_block_delta/message_stop SSE
+// events, which split each event across an "event:" line and a "data:"
+// line, so it tracks the pending event type between Feed calls.
+type anthropicDecoder struct {
+    pendingEvent string
+}
+
+func (d *anthropicDecoder) Feed(line string) (StreamResponse, bool) {
+    if strings.HasPrefix(line, "event: ") {
+        d.pendingEvent = strings.TrimSpace(line[len("event: "):])
+        return StreamResponse{}, false
+    }
+    if !strings.HasPrefix(line, "data: ") {
+        return StreamResponse{}, false
+    }
+    payload := strings.TrimSpace(line[len("data: "):])
+    event := d.pendingEvent
+    d.pendingEvent = ""
+    switch event {
+    case "content_block_delta":
+        var v struct {
+            Delta struct {
+                Text string `json:"text"`
+            } `json:"delta"`
+        }
+        if err := json.Unmarshal([]byte(payload), &v); err != nil {
+            return StreamResponse{Text: payload}, true
+        }
+        return StreamResponse{Text: v.Delta.Text}, true
+    case "message_stop":
+        return StreamResponse{Done: true}, true
+    case "error":
+        var v struct {
+            Error struct {
+                Message string `json:"message"`
+            } `json:"error"`
+        }
+        if err := json.Unmarshal([]byte(payload), &v); err == nil && v.Error.Message != "" {
+            return StreamResponse{Err: errors.New(v.Error.Message)}, true
+        }
+    }
+    return StreamResponse{}, false
+}
+
+// ollamaDecoder parses Ollama's {response, done} NDJSON lines. Unlike the
+// SSE-based decoders, each line is a bare JSON object with no "data: "
+// prefix.
+type ollamaDecoder struct{}
+
+func (ollamaDecoder) Feed(line string) (StreamResponse, bool) {
+    if line == "" {
+        return StreamResponse{}, false
+    }
+    var v struct {
+        Response string `json:"response"`
+        Done     bool   `json:"done"`
+        Error    string `json:"error"`
+    }
+    if err := json.Unmarshal([]byte(line), &v); err != nil {
+        return StreamResponse{Text: line}, true
+    }
+    if v.Error != "" {
+        return StreamResponse{Err: errors.New(v.Error)}, true
+    }
+    return StreamResponse{Text: v.Response, Done: v.Done}, true
+}
+
+// sniffingDecoder buffers nothing but delegates lazily: it inspects the
+// first event it sees (an Anthropic "event:" line, or the JSON shape of the
+// first "data:" payload) to pick a concrete decoder, then delegates every
+// subsequent Feed call to it.
+type sniffingDecoder struct {
+    resolved EventDecoder
+}
+
+func (d *sniffingDecoder) Feed(line string) (StreamResponse, bool) {
+    if d.resolved != nil {
+        return d.resolved.Feed(line)
+    }
+    if strings.HasPrefix(line, "event: ") {
+        d.resolved = &anthropicDecoder{}
+        return d.resolved.Feed(line)
+    }
+    if !strings.HasPrefix(line, "data: ") {
+        // Not SSE framing at all: assume a plain chunked-text response (the
+        // only shape StreamChat supported before multi-backend decoding)
+        // and keep forwarding raw lines through the default decoder.
+        d.resolved = &orbitDecoder{}
+        return d.resolved.Feed(line)
+    }
+    payload := strings.TrimSpace(line[len("data: "):])
+    if payload == "" || payload == "[DONE]" {
+        d.resolved = &orbitDecoder{}
+        return d.resolved.Feed(line)
+    }
+    var shape map[string]json.RawMessage
+    if err := json.Unmarshal([]byte(payload), &shape); err == nil {
+        if _, ok := shape["choices"]; ok {
+            d.resolved = &openAIDecoder{}
+        } else {
+            d.resolved = &orbitDecoder{}
+        }
+    } else {
+        d.resolved = &orbitDecoder{}
+    }
+    return d.resolved.Feed(line)
+}
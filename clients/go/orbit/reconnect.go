@@ -0,0 +1,183 @@
+package orbit
+
+import (
+    "bufio"
+    "context"
+    "errors"
+    "io"
+    "net"
+    "net/http"
+    "strings"
+    "syscall"
+    "time"
+)
+
+const (
+    initialReconnectBackoff = 250 * time.Millisecond
+    maxReconnectBackoff     = 4 * time.Second
+    defaultMaxReconnects    = 5
+)
+
+// WithResume enables opt-in automatic reconnect: on a transient network
+// error mid-stream (connection reset, EOF before a terminal event, or a
+// 502/503/504 on reconnect), the client reopens the request with a
+// Last-Event-ID header and a bounded exponential backoff instead of
+// surfacing the error to the caller. Disabled by default, since it changes
+// StreamChat's error semantics. See ApiClient.MaxReconnects.
+func WithResume() Option {
+    return func(c *ApiClient) { c.resumable = true }
+}
+
+// isRetryableErr reports whether err looks transient (a reset/dropped
+// connection, an EOF before a terminal event, or a timeout) as opposed to a
+// permanent failure (DNS resolution, connection refused, a TLS certificate
+// error, or the caller cancelling ctx) that would just waste MaxReconnects
+// attempts retrying something that will never succeed.
+func isRetryableErr(err error) bool {
+    if err == nil {
+        return false
+    }
+    if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+        return false
+    }
+    if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+        return true
+    }
+    if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) {
+        return true
+    }
+    var netErr net.Error
+    if errors.As(err, &netErr) && netErr.Timeout() {
+        return true
+    }
+    return false
+}
+
+func isRetryableStatus(code int) bool {
+    return code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+// runResumableStream drives a streaming response to completion, transparently
+// reopening the request with Last-Event-ID on transient failures until
+// MaxReconnects is exhausted or a terminal event (Done or Err) arrives.
+func (c *ApiClient) runResumableStream(ctx context.Context, messages []Message, tools []ToolSpec, resp *http.Response, decoder EventDecoder, ch chan<- StreamResponse) {
+    defer close(ch)
+
+    lastEventID := ""
+    seenEventIDs := make(map[string]struct{})
+    backoff := initialReconnectBackoff
+    attempts := 0
+
+    for {
+        terminal, err := c.consumeStream(resp, decoder, ch, &lastEventID, seenEventIDs)
+        if terminal {
+            return
+        }
+        if !isRetryableErr(err) || attempts >= c.MaxReconnects {
+            ch <- StreamResponse{Err: err}
+            return
+        }
+
+        var nextResp *http.Response
+        for {
+            select {
+            case <-time.After(backoff):
+            case <-ctx.Done():
+                ch <- StreamResponse{Err: ctx.Err()}
+                return
+            }
+            if backoff < maxReconnectBackoff {
+                backoff *= 2
+                if backoff > maxReconnectBackoff {
+                    backoff = maxReconnectBackoff
+                }
+            }
+            attempts++
+
+            req, buildErr := c.buildChatRequest(ctx, messages, true, lastEventID, tools)
+            if buildErr != nil {
+                ch <- StreamResponse{Err: buildErr}
+                return
+            }
+            r, doErr := c.http.Do(req)
+            if doErr == nil && r.StatusCode >= 200 && r.StatusCode < 300 {
+                nextResp = r
+                break
+            }
+            var retryable bool
+            if doErr != nil {
+                retryable = isRetryableErr(doErr)
+                err = doErr
+            } else {
+                data, _ := io.ReadAll(r.Body)
+                r.Body.Close()
+                retryable = isRetryableStatus(r.StatusCode)
+                err = errors.New(string(data))
+            }
+            if !retryable || attempts >= c.MaxReconnects {
+                ch <- StreamResponse{Err: err}
+                return
+            }
+        }
+
+        resp = nextResp
+        decoder = c.newStreamDecoder(resp.Header.Get("Content-Type"))
+    }
+}
+
+// consumeStream reads resp's body until a terminal StreamResponse (Done or
+// Err) is emitted to ch or a read error occurs. terminal is true when the
+// caller should stop (success, an unrecoverable server-sent error, or
+// ErrDeadlineExceeded);
+// otherwise err holds the read failure that may be worth reconnecting on.
+//
+// seenEventIDs accumulates every "id: " seen across the lifetime of the
+// resumable stream (including prior reconnects), so that if a reconnect
+// lands on a backend that replays events instead of precisely resuming from
+// Last-Event-ID, the already-delivered ones are swallowed instead of being
+// forwarded to ch a second time.
+func (c *ApiClient) consumeStream(resp *http.Response, decoder EventDecoder, ch chan<- StreamResponse, lastEventID *string, seenEventIDs map[string]struct{}) (terminal bool, err error) {
+    defer resp.Body.Close()
+    reader := bufio.NewReader(resp.Body)
+    idle, absolute := c.deadlineSnapshot()
+    dt := newDeadlineTimer(idle, absolute)
+    defer dt.stop()
+
+    currentEventID := ""
+    for {
+        line, readErr := dt.readLine(reader)
+        if readErr != nil {
+            if errors.Is(readErr, ErrDeadlineExceeded) {
+                ch <- StreamResponse{Err: readErr}
+                return true, nil
+            }
+            return false, readErr
+        }
+        line = strings.TrimSpace(line)
+        if line == "" {
+            continue
+        }
+        if strings.HasPrefix(line, "id: ") {
+            currentEventID = strings.TrimSpace(line[len("id: "):])
+            *lastEventID = currentEventID
+            continue
+        }
+        r, ok := decoder.Feed(line)
+        if !ok {
+            continue
+        }
+        if currentEventID != "" {
+            if _, dup := seenEventIDs[currentEventID]; dup {
+                if r.Err != nil || r.Done {
+                    return true, nil
+                }
+                continue
+            }
+            seenEventIDs[currentEventID] = struct{}{}
+        }
+        ch <- r
+        if r.Err != nil || r.Done {
+            return true, nil
+        }
+    }
+}
@@ -0,0 +1,77 @@
+package orbit
+
+import (
+    "errors"
+    "io"
+    "net/http"
+    "strings"
+    "testing"
+)
+
+func TestIsRetryableErr(t *testing.T) {
+    if isRetryableErr(nil) {
+        t.Fatal("nil error should not be retryable")
+    }
+    if !isRetryableErr(io.EOF) {
+        t.Fatal("io.EOF should be retryable")
+    }
+    if isRetryableErr(errors.New("boom")) {
+        t.Fatal("a plain error should not be retryable")
+    }
+}
+
+func TestIsRetryableStatus(t *testing.T) {
+    for _, code := range []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+        if !isRetryableStatus(code) {
+            t.Fatalf("status %d should be retryable", code)
+        }
+    }
+    if isRetryableStatus(http.StatusBadRequest) {
+        t.Fatal("400 should not be retryable")
+    }
+}
+
+func TestConsumeStreamDedupesByEventID(t *testing.T) {
+    c := &ApiClient{}
+    seen := make(map[string]struct{})
+    lastEventID := ""
+    ch := make(chan StreamResponse, 10)
+
+    body1 := "id: 1\n" +
+        "data: {\"response\":\"a\",\"done\":false}\n" +
+        "id: 2\n" +
+        "data: {\"response\":\"b\",\"done\":false}\n"
+    resp1 := &http.Response{Body: io.NopCloser(strings.NewReader(body1))}
+    if _, err := c.consumeStream(resp1, &orbitDecoder{}, ch, &lastEventID, seen); err != io.EOF {
+        t.Fatalf("expected io.EOF on a body with no terminal event, got %v", err)
+    }
+
+    // Simulate a reconnect where the backend replays events 1 and 2 before
+    // emitting the new, terminal event 3.
+    body2 := "id: 1\n" +
+        "data: {\"response\":\"a\",\"done\":false}\n" +
+        "id: 2\n" +
+        "data: {\"response\":\"b\",\"done\":false}\n" +
+        "id: 3\n" +
+        "data: {\"response\":\"c\",\"done\":true}\n"
+    resp2 := &http.Response{Body: io.NopCloser(strings.NewReader(body2))}
+    terminal, err := c.consumeStream(resp2, &orbitDecoder{}, ch, &lastEventID, seen)
+    if !terminal || err != nil {
+        t.Fatalf("expected terminal completion, got terminal=%v err=%v", terminal, err)
+    }
+
+    close(ch)
+    var texts []string
+    for r := range ch {
+        texts = append(texts, r.Text)
+    }
+    want := []string{"a", "b", "c"}
+    if len(texts) != len(want) {
+        t.Fatalf("got %d responses %v, want %v (replayed events 1 and 2 should have been deduped)", len(texts), texts, want)
+    }
+    for i, text := range want {
+        if texts[i] != text {
+            t.Fatalf("response %d = %q, want %q", i, texts[i], text)
+        }
+    }
+}
@@ -0,0 +1,426 @@
+package orbit
+
+import (
+    "bufio"
+    "context"
+    "crypto/rand"
+    "crypto/sha1"
+    "crypto/tls"
+    "encoding/base64"
+    "encoding/binary"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net"
+    "net/http"
+    "net/url"
+    "strings"
+    "sync"
+)
+
+// Streamer is a bidirectional chat stream: callers interleave Send calls
+// with Recv calls without tearing down the underlying connection per turn.
+type Streamer interface {
+    // Send writes a user turn to the stream.
+    Send(message string) error
+    // Recv blocks for the next assistant delta. It returns io.EOF once the
+    // stream is closed by the server or the caller.
+    Recv() (StreamResponse, error)
+    // Close releases the underlying connection.
+    Close() error
+    // Err returns the first error that terminated the stream, if any.
+    Err() error
+}
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Stream opens a bidirectional chat stream. It first attempts to negotiate
+// a WebSocket upgrade against /v1/chat/ws; if the server doesn't support
+// that endpoint, it transparently falls back to a chunked HTTP streamer that
+// issues one StreamChat-style POST per Send.
+func (c *ApiClient) Stream(ctx context.Context) (Streamer, error) {
+    ws, err := c.dialWebSocket(ctx)
+    if err == nil {
+        return ws, nil
+    }
+    sctx, cancel := context.WithCancel(ctx)
+    return &httpStreamer{ctx: sctx, cancel: cancel, client: c}, nil
+}
+
+func (c *ApiClient) wsEndpoint() (string, error) {
+    u, err := url.Parse(c.apiURL)
+    if err != nil {
+        return "", err
+    }
+    switch u.Scheme {
+    case "https":
+        u.Scheme = "wss"
+    case "http", "":
+        u.Scheme = "ws"
+    }
+    u.Path = strings.TrimRight(strings.TrimSuffix(u.Path, "/v1/chat"), "/") + "/v1/chat/ws"
+    return u.String(), nil
+}
+
+func (c *ApiClient) dialWebSocket(ctx context.Context) (*wsStreamer, error) {
+    wsURL, err := c.wsEndpoint()
+    if err != nil {
+        return nil, err
+    }
+    u, err := url.Parse(wsURL)
+    if err != nil {
+        return nil, err
+    }
+
+    addr := u.Host
+    if !strings.Contains(addr, ":") {
+        if u.Scheme == "wss" {
+            addr += ":443"
+        } else {
+            addr += ":80"
+        }
+    }
+
+    var conn net.Conn
+    dialer := &net.Dialer{}
+    if u.Scheme == "wss" {
+        tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: u.Hostname()}}
+        conn, err = tlsDialer.DialContext(ctx, "tcp", addr)
+    } else {
+        conn, err = dialer.DialContext(ctx, "tcp", addr)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    key := make([]byte, 16)
+    if _, err := rand.Read(key); err != nil {
+        conn.Close()
+        return nil, err
+    }
+    secKey := base64.StdEncoding.EncodeToString(key)
+
+    path := u.Path
+    if u.RawQuery != "" {
+        path += "?" + u.RawQuery
+    }
+    req := "GET " + path + " HTTP/1.1\r\n" +
+        "Host: " + u.Host + "\r\n" +
+        "Upgrade: websocket\r\n" +
+        "Connection: Upgrade\r\n" +
+        "Sec-WebSocket-Key: " + secKey + "\r\n" +
+        "Sec-WebSocket-Version: 13\r\n"
+    if c.apiKey != "" {
+        req += "X-API-Key: " + c.apiKey + "\r\n"
+    }
+    if c.sessionID != "" {
+        req += "X-Session-ID: " + c.sessionID + "\r\n"
+    }
+    req += "\r\n"
+
+    if _, err := conn.Write([]byte(req)); err != nil {
+        conn.Close()
+        return nil, err
+    }
+
+    br := bufio.NewReader(conn)
+    resp, err := http.ReadResponse(br, &http.Request{Method: "GET"})
+    if err != nil {
+        conn.Close()
+        return nil, err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusSwitchingProtocols {
+        conn.Close()
+        return nil, fmt.Errorf("orbit: websocket upgrade failed with status %d", resp.StatusCode)
+    }
+    expectedAccept := computeWebsocketAccept(secKey)
+    if resp.Header.Get("Sec-WebSocket-Accept") != expectedAccept {
+        conn.Close()
+        return nil, errors.New("orbit: websocket upgrade accept mismatch")
+    }
+
+    return &wsStreamer{conn: conn, reader: br}, nil
+}
+
+func computeWebsocketAccept(key string) string {
+    h := sha1.New()
+    h.Write([]byte(key + websocketGUID))
+    return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// wsStreamer is a Streamer backed by a RFC 6455 WebSocket connection. It
+// supports single-frame text messages only, which is sufficient for the
+// small JSON payloads exchanged with the chat endpoint.
+type wsStreamer struct {
+    conn   net.Conn
+    reader *bufio.Reader
+
+    mu     sync.Mutex
+    err    error
+    closed bool
+}
+
+const (
+    wsOpText  = 0x1
+    wsOpClose = 0x8
+)
+
+func (s *wsStreamer) Send(message string) error {
+    payload, err := json.Marshal(map[string]string{"role": "user", "content": message})
+    if err != nil {
+        return err
+    }
+    return s.writeFrame(wsOpText, payload)
+}
+
+func (s *wsStreamer) Recv() (StreamResponse, error) {
+    for {
+        opcode, payload, err := s.readFrame()
+        if err != nil {
+            s.mu.Lock()
+            closed := s.closed
+            s.mu.Unlock()
+            if closed {
+                return StreamResponse{Done: true}, io.EOF
+            }
+            s.setErr(err)
+            return StreamResponse{Err: err}, err
+        }
+        switch opcode {
+        case wsOpClose:
+            s.Close()
+            return StreamResponse{Done: true}, io.EOF
+        case wsOpText:
+            var v map[string]any
+            if err := json.Unmarshal(payload, &v); err != nil {
+                return StreamResponse{Text: string(payload)}, nil
+            }
+            r := StreamResponse{}
+            if text, ok := v["response"].(string); ok {
+                r.Text = text
+            }
+            if done, ok := v["done"].(bool); ok {
+                r.Done = done
+            }
+            if msg, ok := v["error"].(string); ok && msg != "" {
+                r.Err = errors.New(msg)
+                s.setErr(r.Err)
+            }
+            return r, r.Err
+        default:
+            // Ignore ping/pong and other control frames and read the next one.
+            continue
+        }
+    }
+}
+
+func (s *wsStreamer) Close() error {
+    s.mu.Lock()
+    if s.closed {
+        s.mu.Unlock()
+        return nil
+    }
+    s.closed = true
+    s.mu.Unlock()
+    _ = s.writeFrame(wsOpClose, nil)
+    return s.conn.Close()
+}
+
+func (s *wsStreamer) Err() error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.err
+}
+
+func (s *wsStreamer) setErr(err error) {
+    s.mu.Lock()
+    if s.err == nil {
+        s.err = err
+    }
+    s.mu.Unlock()
+}
+
+// writeFrame writes a single, unfragmented client frame. Client-to-server
+// frames must be masked per RFC 6455 section 5.3.
+func (s *wsStreamer) writeFrame(opcode byte, payload []byte) error {
+    var header []byte
+    header = append(header, 0x80|opcode) // FIN + opcode
+
+    n := len(payload)
+    switch {
+    case n <= 125:
+        header = append(header, 0x80|byte(n))
+    case n <= 0xFFFF:
+        header = append(header, 0x80|126)
+        ext := make([]byte, 2)
+        binary.BigEndian.PutUint16(ext, uint16(n))
+        header = append(header, ext...)
+    default:
+        header = append(header, 0x80|127)
+        ext := make([]byte, 8)
+        binary.BigEndian.PutUint64(ext, uint64(n))
+        header = append(header, ext...)
+    }
+
+    mask := make([]byte, 4)
+    if _, err := rand.Read(mask); err != nil {
+        return err
+    }
+    header = append(header, mask...)
+
+    masked := make([]byte, n)
+    for i, b := range payload {
+        masked[i] = b ^ mask[i%4]
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if _, err := s.conn.Write(header); err != nil {
+        return err
+    }
+    _, err := s.conn.Write(masked)
+    return err
+}
+
+// readFrame reads a single, unfragmented server frame. Server-to-client
+// frames are never masked.
+func (s *wsStreamer) readFrame() (byte, []byte, error) {
+    head := make([]byte, 2)
+    if _, err := io.ReadFull(s.reader, head); err != nil {
+        return 0, nil, err
+    }
+    opcode := head[0] & 0x0F
+    n := int64(head[1] & 0x7F)
+
+    switch n {
+    case 126:
+        ext := make([]byte, 2)
+        if _, err := io.ReadFull(s.reader, ext); err != nil {
+            return 0, nil, err
+        }
+        n = int64(binary.BigEndian.Uint16(ext))
+    case 127:
+        ext := make([]byte, 8)
+        if _, err := io.ReadFull(s.reader, ext); err != nil {
+            return 0, nil, err
+        }
+        n = int64(binary.BigEndian.Uint64(ext))
+    }
+
+    payload := make([]byte, n)
+    if _, err := io.ReadFull(s.reader, payload); err != nil {
+        return 0, nil, err
+    }
+    return opcode, payload, nil
+}
+
+// httpStreamer is the Streamer fallback for servers without /v1/chat/ws
+// support: each Send issues a fresh chunked HTTP POST via StreamChat. ctx is
+// a child of the context passed to Stream, cancelled by Close so that
+// closing the streamer mid-turn actually aborts the in-flight request
+// instead of leaking its goroutine and connection.
+type httpStreamer struct {
+    ctx    context.Context
+    cancel context.CancelFunc
+    client *ApiClient
+
+    mu       sync.Mutex
+    ch       <-chan StreamResponse
+    inFlight bool
+    err      error
+    closed   bool
+}
+
+// Send starts a new StreamChat turn. It returns an error if a prior turn's
+// response hasn't been fully drained via Recv yet, since this fallback has
+// only one underlying HTTP response in flight at a time; starting a second
+// one would abandon the first turn's goroutine and response body.
+func (s *httpStreamer) Send(message string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.closed {
+        return errors.New("orbit: streamer is closed")
+    }
+    if s.inFlight {
+        return errors.New("orbit: Send called before the previous turn finished draining via Recv")
+    }
+    ch, err := s.client.StreamChat(s.ctx, message, true)
+    if err != nil {
+        if s.err == nil {
+            s.err = err
+        }
+        return err
+    }
+    s.ch = ch
+    s.inFlight = true
+    return nil
+}
+
+func (s *httpStreamer) Recv() (StreamResponse, error) {
+    s.mu.Lock()
+    ch := s.ch
+    closed := s.closed
+    s.mu.Unlock()
+    if closed {
+        return StreamResponse{Done: true}, io.EOF
+    }
+    if ch == nil {
+        return StreamResponse{}, errors.New("orbit: Recv called before Send")
+    }
+    r, ok := <-ch
+    if !ok {
+        s.mu.Lock()
+        s.inFlight = false
+        s.mu.Unlock()
+        return StreamResponse{Done: true}, io.EOF
+    }
+    if r.Done || r.Err != nil {
+        s.mu.Lock()
+        s.inFlight = false
+        closed := s.closed
+        if r.Err != nil && !closed && s.err == nil {
+            s.err = r.Err
+        }
+        s.mu.Unlock()
+        if r.Err != nil && closed {
+            return StreamResponse{Done: true}, io.EOF
+        }
+    }
+    if r.Err != nil {
+        return r, r.Err
+    }
+    return r, nil
+}
+
+// Close cancels the streamer's context, which aborts any in-flight request,
+// and drains the producer goroutine's remaining sends so it can observe the
+// resulting error and exit instead of blocking forever on an unbuffered
+// channel nobody reads from anymore.
+func (s *httpStreamer) Close() error {
+    s.mu.Lock()
+    if s.closed {
+        s.mu.Unlock()
+        return nil
+    }
+    s.closed = true
+    ch := s.ch
+    inFlight := s.inFlight
+    s.mu.Unlock()
+
+    s.cancel()
+    if inFlight && ch != nil {
+        go func() {
+            for range ch {
+            }
+        }()
+    }
+    return nil
+}
+
+func (s *httpStreamer) Err() error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.err
+}
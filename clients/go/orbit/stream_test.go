@@ -0,0 +1,89 @@
+package orbit
+
+import (
+    "bufio"
+    "encoding/json"
+    "net"
+    "testing"
+)
+
+func TestWsStreamerWriteFrameIsMaskedAndRoundTrips(t *testing.T) {
+    client, server := net.Pipe()
+    defer client.Close()
+    defer server.Close()
+
+    s := &wsStreamer{conn: client, reader: bufio.NewReader(client)}
+    done := make(chan error, 1)
+    go func() { done <- s.Send("hello") }()
+
+    head := make([]byte, 2)
+    if _, err := readFull(server, head); err != nil {
+        t.Fatalf("reading frame header: %v", err)
+    }
+    if head[0] != 0x80|wsOpText {
+        t.Fatalf("header byte 0 = %#x, want FIN+text opcode", head[0])
+    }
+    if head[1]&0x80 == 0 {
+        t.Fatal("client-to-server frame must have the mask bit set")
+    }
+    n := int(head[1] & 0x7F)
+
+    mask := make([]byte, 4)
+    if _, err := readFull(server, mask); err != nil {
+        t.Fatalf("reading mask key: %v", err)
+    }
+    masked := make([]byte, n)
+    if _, err := readFull(server, masked); err != nil {
+        t.Fatalf("reading payload: %v", err)
+    }
+    payload := make([]byte, n)
+    for i := range masked {
+        payload[i] = masked[i] ^ mask[i%4]
+    }
+
+    var v map[string]string
+    if err := json.Unmarshal(payload, &v); err != nil {
+        t.Fatalf("unmasked payload isn't valid JSON: %v", err)
+    }
+    if v["role"] != "user" || v["content"] != "hello" {
+        t.Fatalf("unexpected payload: %+v", v)
+    }
+    if err := <-done; err != nil {
+        t.Fatalf("Send returned an error: %v", err)
+    }
+}
+
+func TestWsStreamerReadFrameUnmaskedServerFrame(t *testing.T) {
+    client, server := net.Pipe()
+    defer client.Close()
+    defer server.Close()
+
+    payload := []byte(`{"response":"hi","done":true}`)
+    frame := []byte{0x80 | wsOpText, byte(len(payload))}
+    frame = append(frame, payload...)
+    go func() { server.Write(frame) }()
+
+    s := &wsStreamer{conn: client, reader: bufio.NewReader(client)}
+    opcode, got, err := s.readFrame()
+    if err != nil {
+        t.Fatalf("readFrame: %v", err)
+    }
+    if opcode != wsOpText {
+        t.Fatalf("opcode = %#x, want wsOpText", opcode)
+    }
+    if string(got) != string(payload) {
+        t.Fatalf("payload = %q, want %q", got, payload)
+    }
+}
+
+func readFull(conn net.Conn, buf []byte) (int, error) {
+    total := 0
+    for total < len(buf) {
+        n, err := conn.Read(buf[total:])
+        total += n
+        if err != nil {
+            return total, err
+        }
+    }
+    return total, nil
+}
@@ -0,0 +1,144 @@
+package orbit
+
+import "context"
+
+// ToolSpec describes a callable tool offered to the model, using the same
+// JSON-schema-parameters shape as OpenAI-compatible function calling.
+type ToolSpec struct {
+    Name        string         `json:"name"`
+    Description string         `json:"description,omitempty"`
+    Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+// ToolCall is a fully accumulated tool invocation requested by the model
+// mid-stream.
+type ToolCall struct {
+    ID        string
+    Name      string
+    Arguments string // raw JSON arguments
+}
+
+// ToolHandler executes a tool call and returns the result to report back to
+// the model as a "tool" role message.
+type ToolHandler func(ToolCall) (string, error)
+
+// ToolCallRequest is the outbound, OpenAI-compatible shape of a tool call on
+// an assistant message, letting a later {role: "tool", tool_call_id: ...}
+// message correlate back to it.
+type ToolCallRequest struct {
+    ID       string `json:"id"`
+    Type     string `json:"type"`
+    Function struct {
+        Name      string `json:"name"`
+        Arguments string `json:"arguments"`
+    } `json:"function"`
+}
+
+// toolCallDelta is the wire shape of one streamed tool-call fragment,
+// shared by the orbit and OpenAI-compatible decoders. Only arguments arrive
+// incrementally; id and the function name are normally only present on the
+// first fragment of a given index.
+type toolCallDelta struct {
+    Index    int    `json:"index"`
+    ID       string `json:"id"`
+    Function struct {
+        Name      string `json:"name"`
+        Arguments string `json:"arguments"`
+    } `json:"function"`
+}
+
+// toolCallAccumulator merges streamed tool-call deltas into a complete
+// ToolCall. Only a single in-flight tool call is supported: StreamChat
+// reports the first one it sees and ignores the rest, since
+// StreamChatWithTools only drives one call/response round-trip per turn.
+// "First" means first-seen index, not index 0 — the delta schema allows a
+// lone tool call to stream at any index when there's a preceding or parallel
+// call slot, so the accumulator tracks whichever index it saw first.
+type toolCallAccumulator struct {
+    calls      map[int]*ToolCall
+    firstIndex int
+    haveFirst  bool
+}
+
+// feed merges deltas into the accumulator and, once done is true, returns
+// the first-seen accumulated call (nil if none were seen this turn).
+func (a *toolCallAccumulator) feed(deltas []toolCallDelta, done bool) *ToolCall {
+    for _, d := range deltas {
+        if a.calls == nil {
+            a.calls = make(map[int]*ToolCall)
+        }
+        tc, ok := a.calls[d.Index]
+        if !ok {
+            tc = &ToolCall{}
+            a.calls[d.Index] = tc
+            if !a.haveFirst {
+                a.firstIndex = d.Index
+                a.haveFirst = true
+            }
+        }
+        if d.ID != "" {
+            tc.ID = d.ID
+        }
+        if d.Function.Name != "" {
+            tc.Name = d.Function.Name
+        }
+        tc.Arguments += d.Function.Arguments
+    }
+    if !done || !a.haveFirst {
+        return nil
+    }
+    return a.calls[a.firstIndex]
+}
+
+// StreamChatWithTools drives a tool-calling exchange: it sends message with
+// the available tools, and whenever the model emits a complete tool call,
+// invokes handler and posts the result back as a {role: "tool"} message,
+// continuing the exchange until a terminal assistant message arrives with
+// no further tool call.
+func (c *ApiClient) StreamChatWithTools(ctx context.Context, message string, tools []ToolSpec, handler ToolHandler) (<-chan StreamResponse, error) {
+    messages := []Message{{Role: "user", Content: message}}
+    out := make(chan StreamResponse)
+    go func() {
+        defer close(out)
+        for {
+            ch, err := c.streamMessages(ctx, messages, true, tools)
+            if err != nil {
+                out <- StreamResponse{Err: err}
+                return
+            }
+
+            var toolCall *ToolCall
+            var assistantText string
+            for r := range ch {
+                if r.ToolCall != nil {
+                    toolCall = r.ToolCall
+                    continue
+                }
+                assistantText += r.Text
+                out <- r
+                if r.Err != nil {
+                    return
+                }
+            }
+
+            if toolCall == nil {
+                return
+            }
+            result, err := handler(*toolCall)
+            if err != nil {
+                out <- StreamResponse{Err: err}
+                return
+            }
+            assistantMsg := Message{Role: "assistant", Content: assistantText}
+            assistantMsg.ToolCalls = []ToolCallRequest{{ID: toolCall.ID, Type: "function"}}
+            assistantMsg.ToolCalls[0].Function.Name = toolCall.Name
+            assistantMsg.ToolCalls[0].Function.Arguments = toolCall.Arguments
+
+            messages = append(messages,
+                assistantMsg,
+                Message{Role: "tool", Content: result, ToolCallID: toolCall.ID},
+            )
+        }
+    }()
+    return out, nil
+}
@@ -0,0 +1,41 @@
+package orbit
+
+import "testing"
+
+func TestToolCallAccumulatorFeed_NonZeroIndex(t *testing.T) {
+    var a toolCallAccumulator
+    deltas := []toolCallDelta{{Index: 2, ID: "call_1"}}
+    deltas[0].Function.Name = "get_weather"
+    deltas[0].Function.Arguments = `{"city":`
+
+    if tc := a.feed(deltas, false); tc != nil {
+        t.Fatalf("feed returned non-nil before done: %+v", tc)
+    }
+
+    deltas = []toolCallDelta{{Index: 2}}
+    deltas[0].Function.Arguments = `"sf"}`
+    tc := a.feed(deltas, true)
+    if tc == nil {
+        t.Fatal("feed returned nil after done with an accumulated call at index 2")
+    }
+    if tc.ID != "call_1" || tc.Name != "get_weather" || tc.Arguments != `{"city":"sf"}` {
+        t.Fatalf("unexpected accumulated call: %+v", tc)
+    }
+}
+
+func TestToolCallAccumulatorFeed_NoCalls(t *testing.T) {
+    var a toolCallAccumulator
+    if tc := a.feed(nil, true); tc != nil {
+        t.Fatalf("feed returned non-nil with no deltas ever seen: %+v", tc)
+    }
+}
+
+func TestToolCallAccumulatorFeed_FirstSeenIndexWins(t *testing.T) {
+    var a toolCallAccumulator
+    a.feed([]toolCallDelta{{Index: 3, ID: "call_a"}}, false)
+    a.feed([]toolCallDelta{{Index: 1, ID: "call_b"}}, false)
+    tc := a.feed(nil, true)
+    if tc == nil || tc.ID != "call_a" {
+        t.Fatalf("expected the first-seen index (3) to win, got %+v", tc)
+    }
+}